@@ -16,17 +16,21 @@ package cmd
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"sync"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	remoteUtils "k8s.io/apimachinery/pkg/util/remotecommand"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	api "k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/remotecommand"
 
 	"strconv"
@@ -40,6 +44,25 @@ import (
 var execContainerFlag string
 var stdinFlag bool
 var ttyFlag bool
+var waitFlag time.Duration
+var parallelFlag int
+var outputFlag string
+
+// PodExecResult captures the outcome of execing into a single pod, so a failure on one pod
+// doesn't abort the whole command.
+type PodExecResult struct {
+	Pod string
+	Err error
+}
+
+// execLine is the structured form a single line of output takes under --output=json|ndjson.
+type execLine struct {
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	Ts        string `json:"ts"`
+	Stream    string `json:"stream"`
+	Line      string `json:"line"`
+}
 
 // execCmd represents the exec command
 var execCmd = &cobra.Command{
@@ -59,6 +82,12 @@ argonaut exec "app=hello" sh -li
 
 # Opening an interactive shell TTY session with all "app=hello" pods & colored output labels
 argonaut exec "app=hello" sh -lit
+
+# Wait up to 30s for pods from a rolling deployment to become ready before execing
+argonaut exec "app=hello" --wait 30s date
+
+# Run at most 5 pods concurrently and emit newline-delimited JSON for downstream tooling
+argonaut exec "app=hello" --parallel 5 --output ndjson date
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) < 1 {
@@ -75,15 +104,40 @@ argonaut exec "app=hello" sh -lit
 
 		command := args[1]
 
-		client, err := utils.GetClient()
+		if outputFlag != "text" && outputFlag != "json" && outputFlag != "ndjson" {
+			fmt.Printf("Invalid --output %q, expected text, json or ndjson\n", outputFlag)
+			return
+		}
+
+		client, err := utils.GetClientWithOptions(configOptions())
 		if err != nil {
 			fmt.Println(err)
 			return
 		}
 
-		err = MultiExec(client, labelSelector, command, namespaceFlag, execContainerFlag, stdinFlag, ttyFlag, colorFlag)
+		results, err := MultiExec(client, labelSelector, command, ExecOptions{
+			Namespace:      namespaceFlag,
+			Container:      execContainerFlag,
+			Stdin:          stdinFlag,
+			Tty:            ttyFlag,
+			UseColor:       colorFlag,
+			Wait:           waitFlag,
+			Parallel:       parallelFlag,
+			Output:         outputFlag,
+			FieldSelector:  fieldSelectorFlag,
+			PodNameRegex:   podRegexFlag,
+			Node:           nodeFlag,
+			ExtraSelectors: selectorFlag,
+		})
 		if err != nil {
 			fmt.Println(err)
+			return
+		}
+
+		for _, result := range results {
+			if result.Err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", result.Pod, result.Err)
+			}
 		}
 	},
 }
@@ -94,17 +148,49 @@ func init() {
 	execCmd.Flags().StringVarP(&execContainerFlag, "container", "c", "", "Container name. If omitted, the first container in the pod will be chosen")
 	execCmd.Flags().BoolVarP(&stdinFlag, "stdin", "i", false, "Pass stdin to the container")
 	execCmd.Flags().BoolVarP(&ttyFlag, "tty", "t", false, "Stdin is a TTY")
+	execCmd.Flags().DurationVar(&waitFlag, "wait", 0, "Wait up to this long for pending/initializing pods to become ready before execing")
+	execCmd.Flags().IntVar(&parallelFlag, "parallel", 0, "Maximum number of pods to exec into concurrently. Defaults to one per matched pod")
+	execCmd.Flags().StringVar(&outputFlag, "output", "text", "Output format: text, ndjson (one json object per line, streamed live) or json (one json array printed once every pod is done)")
+}
+
+// ExecOptions bundles the exec flags that sit alongside the labelSelector/command being run, so
+// MultiExec doesn't keep growing a positional parameter with every request that touches it.
+type ExecOptions struct {
+	Namespace      string
+	Container      string
+	Stdin          bool
+	Tty            bool
+	UseColor       bool
+	Wait           time.Duration
+	Parallel       int
+	Output         string
+	FieldSelector  string
+	PodNameRegex   string
+	Node           string
+	ExtraSelectors []string
 }
 
-// MultiExec applies the
-func MultiExec(client *kubernetes.Clientset, labelSelector string, command string, namespace string, container string, stdin bool, tty bool, useColor bool) (err error) {
-	// parse given label selector
-	selector, err := labels.Parse(labelSelector)
+// MultiExec applies the given command to every pod matching labelSelector, narrowed by
+// opts.FieldSelector, opts.PodNameRegex and opts.Node, and ANDed with any opts.ExtraSelectors.
+// Pods that are not yet ready are given up to opts.Wait to become so; pods that never become
+// ready, along with pods that fail to exec, are reported back as PodExecResult entries rather
+// than aborting the whole command on the first failure. Concurrency is bounded to opts.Parallel
+// streams at a time (0 means one per matched pod). Output is colored text, streamed ndjson (one
+// json object per line), or a single json array printed once every pod's stream has finished.
+func MultiExec(client *kubernetes.Clientset, labelSelector string, command string, opts ExecOptions) (results []PodExecResult, err error) {
+	// parse given label selector(s)
+	selector, err := buildSelector(labelSelector, opts.ExtraSelectors)
 	if err != nil {
-		return
+		return nil, err
+	}
+
+	resolvedFieldSelector, err := buildFieldSelector(opts.FieldSelector, opts.Node)
+	if err != nil {
+		return nil, err
 	}
 
 	// determine namespace to query
+	namespace := opts.Namespace
 	if namespace == "" {
 		namespace = api.NamespaceDefault
 	}
@@ -113,136 +199,335 @@ func MultiExec(client *kubernetes.Clientset, labelSelector string, command strin
 
 	// retrieve all pods by label selector
 	pods, err := podIntr.List(metav1.ListOptions{
-		FieldSelector: fields.Everything().String(),
+		FieldSelector: resolvedFieldSelector,
 		LabelSelector: selector.String(),
 	})
 	if err != nil {
-		return
+		return nil, err
+	}
+
+	podItems, err := filterPodsByName(pods.Items, opts.PodNameRegex)
+	if err != nil {
+		return nil, err
 	}
 
 	// notify caller that there were no pods
-	if len(pods.Items) == 0 {
-		return fmt.Errorf("No pods in namespace: %s", namespace)
+	if len(podItems) == 0 {
+		return nil, fmt.Errorf("No pods in namespace: %s", namespace)
+	}
+
+	// gate the pod list on readiness, optionally waiting for pending/initializing pods to catch up
+	ready, pending := partitionByReadiness(podItems)
+	if len(pending) > 0 && opts.Wait > 0 {
+		names := make(map[string]bool, len(pending))
+		for name := range pending {
+			names[name] = true
+		}
+
+		failures := waitForPodsReady(client, namespace, selector, names, opts.Wait)
+		for name, pod := range pending {
+			if failures[name] != nil {
+				results = append(results, PodExecResult{Pod: name, Err: failures[name]})
+				continue
+			}
+
+			ready = append(ready, pod)
+		}
+	} else {
+		for name := range pending {
+			results = append(results, PodExecResult{Pod: name, Err: fmt.Errorf("pod is not ready")})
+		}
 	}
 
+	if len(ready) == 0 {
+		return results, nil
+	}
+
+	if err := validateStdinParallel(opts.Stdin, opts.Tty, opts.Parallel, len(ready)); err != nil {
+		return results, err
+	}
+
+	sem := make(chan struct{}, concurrencyLimit(opts.Parallel, len(ready)))
+
 	var wg sync.WaitGroup
 	var printLock sync.Mutex
-	var stdinIO io.Reader
+	var resultLock sync.Mutex
 	var col *color.Color
 	var writes []*io.PipeWriter
 	colorLen := len(colors)
+	structured := opts.Output != "text"
 
-	if stdin {
-		stdinIO = os.Stdin
+	restConf, err := utils.GetK8sRestConfigWithOptions(configOptions())
+	if err != nil {
+		return results, err
 	}
 
-	restConf, err := utils.GetK8sRestConfig()
-	if err != nil {
-		return err
+	recordResult := func(pod string, err error) {
+		resultLock.Lock()
+		results = append(results, PodExecResult{Pod: pod, Err: err})
+		resultLock.Unlock()
 	}
 
-	// podExecOpts := &api.PodExecOptions{
-	// 	Container: container,
-	// 	Command:   strings.Split(command, " "),
-	// 	Stdin:     stdin, // let stdin flag decide
-	// 	Stdout:    true,
-	// 	Stderr:    true,
-	// 	TTY:       tty, // let tty flag decide
-	// }
+	emit, collectedLines := newEmitter(opts.Output, os.Stdout, &printLock)
 
 	// start exec'ing on these pods
-	for ndx, pod := range pods.Items {
+	for ndx, pod := range ready {
 		req := client.CoreV1().RESTClient().Post().
 			Resource(api.ResourcePods.String()).
 			Name(pod.Name).
 			Namespace(pod.Namespace).
 			SubResource("exec").
-			Param("container", container).
+			Param("container", opts.Container).
 			Param("command", command).
-			Param("stdin", strconv.FormatBool(stdin)).
+			Param("stdin", strconv.FormatBool(opts.Stdin)).
 			Param("stdout", strconv.FormatBool(true)).
 			Param("stderr", strconv.FormatBool(true)).
-			Param("tty", strconv.FormatBool(tty))
-
-		// VersionedParams(podExecOpts, metav1.ParameterCodec)
-
-		// fmt.Printf("Request: %+v\n", req)
+			Param("tty", strconv.FormatBool(opts.Tty))
 
 		streamExec, err := remotecommand.NewExecutor(restConf, "POST", req.URL())
 		if err != nil {
-			return err
+			recordResult(pod.Name, err)
+			continue
 		}
 
-		if useColor {
+		if opts.UseColor {
 			col = colors[ndx%colorLen] // give this stream one of the set colors
 		} else {
 			color.NoColor = true           // turn off all colors
 			col = color.New(color.FgWhite) // set color to white to be safe
 		}
 
-		if tty || stdin {
-			wg.Add(2)
-
-			rtRead, mainWrite := io.Pipe()     // create main->routine pipe
-			writes = append(writes, mainWrite) // keep track of main's writing end
+		rtStdoutRead, rtStdoutWrite := io.Pipe() // routine->main pipe for stdout
+		wg.Add(2)                                // the exec goroutine and the stdout reader
+
+		var rtStderrRead *io.PipeReader
+		var rtStderrWrite *io.PipeWriter
+		stderrOpt := io.Writer(os.Stderr)
+		if structured {
+			// give this pod its own stderr pipe so lines can be tagged instead of merged into os.Stderr
+			rtStderrRead, rtStderrWrite = io.Pipe()
+			stderrOpt = rtStderrWrite
+			wg.Add(1)
+		}
 
-			mainRead, rtWrite := io.Pipe() // create routine->main pipe
+		var stdinOpt io.Reader
+		if opts.Tty || opts.Stdin {
+			rtStdinRead, mainWrite := io.Pipe() // main->routine pipe for stdin
+			writes = append(writes, mainWrite)  // keep track of main's writing end
+			stdinOpt = rtStdinRead
+		} else if !structured {
+			col.Printf("\"%s\" for pod %s:\n", command, pod.Name)
+		}
 
-			opts := remotecommand.StreamOptions{
-				SupportedProtocols: remoteUtils.SupportedStreamingProtocols,
-				Stdin:              rtRead,
-				Stdout:             rtWrite,
-				Stderr:             os.Stderr,
-				Tty:                tty,
-			}
+		streamOpts := remotecommand.StreamOptions{
+			SupportedProtocols: remoteUtils.SupportedStreamingProtocols,
+			Stdin:              stdinOpt,
+			Stdout:             rtStdoutWrite,
+			Stderr:             stderrOpt,
+			Tty:                opts.Tty,
+		}
 
-			// start threads
-			go openPodSession(streamExec, opts, pod.Name, &wg, col)
-			go readRoutineToStdout(pod.Name, mainRead, &wg, col, &printLock)
-		} else {
-			col.Printf("\"%s\" for pod %s:\n", command, pod.Name)
+		go func(exec remotecommand.StreamExecutor, streamOpts remotecommand.StreamOptions, stdoutWrite *io.PipeWriter, stderrWrite *io.PipeWriter, podName string) {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			defer wg.Done()
 
-			opts := remotecommand.StreamOptions{
-				SupportedProtocols: remoteUtils.SupportedStreamingProtocols,
-				Stdin:              stdinIO,
-				Stdout:             os.Stdout,
-				Stderr:             os.Stderr,
-				Tty:                false,
+			err := exec.Stream(streamOpts)
+			stdoutWrite.CloseWithError(err)
+			if stderrWrite != nil {
+				stderrWrite.CloseWithError(err)
 			}
-			// this shouldn't have tty == true, b.c it should be a one-off command
-			err = streamExec.Stream(opts)
+
+			recordResult(podName, err)
 			if err != nil {
-				return err
+				fmt.Fprintln(os.Stderr, "Error from routine for", podName, ":", err)
 			}
+		}(streamExec, streamOpts, rtStdoutWrite, rtStderrWrite, pod.Name)
+
+		go readRoutineToOutput(pod.Name, opts.Container, "stdout", rtStdoutRead, &wg, col, &printLock, opts.Output, emit)
+		if rtStderrRead != nil {
+			go readRoutineToOutput(pod.Name, opts.Container, "stderr", rtStderrRead, &wg, col, &printLock, opts.Output, emit)
 		}
 	}
 
-	if tty || stdin { // if using stdin or a tty, buffer os.Stdin and write to all consumers
+	if opts.Tty || opts.Stdin { // if using stdin or a tty, buffer os.Stdin and write to all consumers
 		err = stdinToPods(writes)
 		if err != nil {
-			return err
+			return results, err
 		}
+	}
 
+	if !structured { // keep stdout pure json/ndjson under structured output
 		fmt.Println("Waiting for threads...")
+	}
+	wg.Wait()
 
-		wg.Wait()
+	// json (unlike ndjson) prints one array document covering every pod, once everything is done
+	if opts.Output == "json" {
+		collected := collectedLines()
+		if collected == nil {
+			collected = []execLine{} // marshal as [] rather than null when no line was emitted
+		}
+
+		encoded, err := json.MarshalIndent(collected, "", "  ")
+		if err != nil {
+			return results, err
+		}
+
+		fmt.Println(string(encoded))
 	}
 
-	return
+	return results, nil
 }
 
-// opens a stream with a pod as configured  by the given remote command, should be run in a go routine
-func openPodSession(rmtCmd remotecommand.StreamExecutor, opts remotecommand.StreamOptions, podName string, wg *sync.WaitGroup, col *color.Color) {
-	defer wg.Done()
+// concurrencyLimit resolves the --parallel flag to a sem buffer size: parallel itself when
+// positive, or one slot per ready pod (i.e. unbounded) when parallel is 0 or negative.
+func concurrencyLimit(parallel int, readyCount int) int {
+	if parallel <= 0 {
+		return readyCount
+	}
 
-	col.Printf("session for pod %s active\n", podName)
-	err := rmtCmd.Stream(opts)
-	if err != nil {
-		fmt.Println("Error from routine for", podName, ":", err)
-		return
+	return parallel
+}
+
+// newEmitter builds the emit func used to hand a structured output line to the caller according
+// to output: "ndjson" streams one json object per line to w as it arrives (under printLock, so it
+// doesn't interleave with anything else writing to w); anything else accumulates lines instead,
+// retrievable via the returned lines func, so the whole run can be printed back as a single json
+// array once every pod is done.
+func newEmitter(output string, w io.Writer, printLock *sync.Mutex) (emit func(execLine), lines func() []execLine) {
+	var linesLock sync.Mutex
+	var accumulated []execLine
+	encoder := json.NewEncoder(w)
+
+	emit = func(l execLine) {
+		if output == "ndjson" {
+			printLock.Lock()
+			encoder.Encode(l)
+			printLock.Unlock()
+			return
+		}
+
+		linesLock.Lock()
+		accumulated = append(accumulated, l)
+		linesLock.Unlock()
+	}
+
+	lines = func() []execLine {
+		linesLock.Lock()
+		defer linesLock.Unlock()
+		return accumulated
+	}
+
+	return emit, lines
+}
+
+// validateStdinParallel rejects combining --stdin/--tty with a --parallel bound below the number
+// of ready pods. stdin/tty fan-out writes to every pod's pipe synchronously and in order; a pod
+// queued behind the concurrency limit hasn't started reading its pipe yet, so writing to it would
+// block the fan-out (and every pod after it) forever.
+func validateStdinParallel(stdin bool, tty bool, parallel int, readyCount int) error {
+	if (stdin || tty) && parallel > 0 && parallel < readyCount {
+		return fmt.Errorf("--stdin/--tty require --parallel to be 0 or >= the number of ready pods (%d ready, --parallel %d)", readyCount, parallel)
+	}
+
+	return nil
+}
+
+// partitionByReadiness splits pods into those that are ready (Running phase, all containers
+// ready) and those that are still pending or initializing, keyed by pod name.
+func partitionByReadiness(pods []api.Pod) (ready []api.Pod, pending map[string]api.Pod) {
+	pending = make(map[string]api.Pod)
+
+	for _, pod := range pods {
+		if isPodReady(&pod) {
+			ready = append(ready, pod)
+		} else {
+			pending[pod.Name] = pod
+		}
 	}
 
-	return
+	return ready, pending
+}
+
+// isPodReady reports whether a pod is Running and every container in it is Ready.
+func isPodReady(pod *api.Pod) bool {
+	if pod.Status.Phase != api.PodRunning {
+		return false
+	}
+
+	for _, cs := range pod.Status.ContainerStatuses {
+		if !cs.Ready {
+			return false
+		}
+	}
+
+	return true
+}
+
+// waitForPodsReady watches pods matching selector in namespace, returning once every pod named
+// in pending is ready or timeout elapses. The returned map holds an error for each pod that
+// never became ready in time.
+func waitForPodsReady(client *kubernetes.Clientset, namespace string, selector labels.Selector, pending map[string]bool, timeout time.Duration) map[string]error {
+	var mu sync.Mutex
+	remaining := len(pending)
+	done := make(chan struct{})
+
+	handle := func(obj interface{}) {
+		pod, ok := obj.(*api.Pod)
+		if !ok {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !pending[pod.Name] || !isPodReady(pod) {
+			return
+		}
+
+		delete(pending, pod.Name)
+		remaining--
+		if remaining == 0 {
+			close(done)
+		}
+	}
+
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.LabelSelector = selector.String()
+			return client.CoreV1().Pods(namespace).List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.LabelSelector = selector.String()
+			return client.CoreV1().Pods(namespace).Watch(options)
+		},
+	}
+
+	informer := cache.NewSharedInformer(lw, &api.Pod{}, 0)
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    handle,
+		UpdateFunc: func(old, new interface{}) { handle(new) },
+	})
+
+	stopCh := make(chan struct{})
+	go informer.Run(stopCh)
+	defer close(stopCh)
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	failures := make(map[string]error, len(pending))
+	for name := range pending {
+		failures[name] = fmt.Errorf("pod did not become ready within %s", timeout)
+	}
+
+	return failures
 }
 
 // writes stdin from user to all pipes
@@ -278,17 +563,32 @@ func writeToPods(writes []*io.PipeWriter, input string) error {
 	return nil
 }
 
-// reads data from given read-in pipe, writes it stdout with a buffer
-func readRoutineToStdout(name string, read *io.PipeReader, wg *sync.WaitGroup, col *color.Color, printLock *sync.Mutex) {
+// readRoutineToOutput reads line by line from a single pod/stream pipe. Under format == "text"
+// each line is printed as colored text; otherwise it's handed to emit as a structured execLine
+// tagging the pod, container and stream it came from, for the caller to stream (ndjson) or
+// accumulate (json) as appropriate.
+func readRoutineToOutput(name string, container string, stream string, read *io.PipeReader, wg *sync.WaitGroup, col *color.Color, printLock *sync.Mutex, format string, emit func(execLine)) {
 	defer wg.Done()
 
 	// buffer each line before writing to stdout
 	scanner := bufio.NewScanner(read)
 	for scanner.Scan() {
-		printLock.Lock() // request printing lock
-		col.Printf("%s: ", name)
-		fmt.Println(vtclean.Clean(scanner.Text(), false))
-		printLock.Unlock() // unlock printing lock so other threads can print
+		line := vtclean.Clean(scanner.Text(), false)
+
+		if format == "text" {
+			printLock.Lock() // request printing lock
+			col.Printf("%s: ", name)
+			fmt.Println(line)
+			printLock.Unlock() // unlock printing lock so other threads can print
+		} else {
+			emit(execLine{
+				Pod:       name,
+				Container: container,
+				Ts:        time.Now().UTC().Format(time.RFC3339Nano),
+				Stream:    stream,
+				Line:      line,
+			})
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
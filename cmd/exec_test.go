@@ -0,0 +1,221 @@
+// Copyright © 2016 Apigee Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/client-go/pkg/api/v1"
+)
+
+func TestNewEmitterNdjsonStreamsEachLine(t *testing.T) {
+	var buf bytes.Buffer
+	var printLock sync.Mutex
+	emit, lines := newEmitter("ndjson", &buf, &printLock)
+
+	emit(execLine{Pod: "a", Stream: "stdout", Line: "one"})
+	emit(execLine{Pod: "a", Stream: "stderr", Line: "two"})
+
+	got := strings.TrimRight(buf.String(), "\n")
+	rows := strings.Split(got, "\n")
+	if len(rows) != 2 {
+		t.Fatalf("ndjson output = %q, want 2 lines", buf.String())
+	}
+
+	var first execLine
+	if err := json.Unmarshal([]byte(rows[0]), &first); err != nil {
+		t.Fatalf("unmarshaling first ndjson line: %v", err)
+	}
+	if first.Stream != "stdout" || first.Line != "one" {
+		t.Errorf("first line = %+v, want stream=stdout line=one", first)
+	}
+
+	if lines() != nil {
+		t.Errorf("lines() = %v, want nil in ndjson mode", lines())
+	}
+}
+
+func TestNewEmitterJSONAccumulatesLines(t *testing.T) {
+	var buf bytes.Buffer
+	var printLock sync.Mutex
+	emit, lines := newEmitter("json", &buf, &printLock)
+
+	emit(execLine{Pod: "a", Stream: "stdout", Line: "one"})
+	emit(execLine{Pod: "b", Stream: "stderr", Line: "two"})
+
+	if buf.Len() != 0 {
+		t.Errorf("json mode wrote %q to the stream, want nothing written until the caller marshals lines()", buf.String())
+	}
+
+	got := lines()
+	if len(got) != 2 {
+		t.Fatalf("lines() = %v, want 2 entries", got)
+	}
+	if got[0].Pod != "a" || got[1].Pod != "b" {
+		t.Errorf("lines() = %+v, want pods in emit order [a, b]", got)
+	}
+}
+
+func TestIsPodReady(t *testing.T) {
+	tests := []struct {
+		name string
+		pod  *api.Pod
+		want bool
+	}{
+		{
+			name: "running with all containers ready",
+			pod: &api.Pod{
+				Status: api.PodStatus{
+					Phase:             api.PodRunning,
+					ContainerStatuses: []api.ContainerStatus{{Ready: true}, {Ready: true}},
+				},
+			},
+			want: true,
+		},
+		{
+			name: "running with one container not ready",
+			pod: &api.Pod{
+				Status: api.PodStatus{
+					Phase:             api.PodRunning,
+					ContainerStatuses: []api.ContainerStatus{{Ready: true}, {Ready: false}},
+				},
+			},
+			want: false,
+		},
+		{
+			name: "not running",
+			pod: &api.Pod{
+				Status: api.PodStatus{
+					Phase:             api.PodPending,
+					ContainerStatuses: []api.ContainerStatus{{Ready: true}},
+				},
+			},
+			want: false,
+		},
+		{
+			name: "running with no container statuses",
+			pod:  &api.Pod{Status: api.PodStatus{Phase: api.PodRunning}},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPodReady(tt.pod); got != tt.want {
+				t.Errorf("isPodReady(%+v) = %v, want %v", tt.pod.Status, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartitionByReadiness(t *testing.T) {
+	readyPod := api.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "ready-pod"},
+		Status: api.PodStatus{
+			Phase:             api.PodRunning,
+			ContainerStatuses: []api.ContainerStatus{{Ready: true}},
+		},
+	}
+	pendingPod := api.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pending-pod"},
+		Status:     api.PodStatus{Phase: api.PodPending},
+	}
+
+	ready, pending := partitionByReadiness([]api.Pod{readyPod, pendingPod})
+
+	if len(ready) != 1 || ready[0].Name != "ready-pod" {
+		t.Errorf("ready = %v, want [ready-pod]", ready)
+	}
+
+	if len(pending) != 1 {
+		t.Fatalf("pending = %v, want 1 entry", pending)
+	}
+	if _, ok := pending["pending-pod"]; !ok {
+		t.Errorf("pending = %v, want key %q", pending, "pending-pod")
+	}
+}
+
+func TestValidateStdinParallel(t *testing.T) {
+	tests := []struct {
+		name       string
+		stdin      bool
+		tty        bool
+		parallel   int
+		readyCount int
+		wantErr    bool
+	}{
+		{name: "no stdin or tty, bounded parallel", stdin: false, tty: false, parallel: 2, readyCount: 5},
+		{name: "stdin with unbounded parallel", stdin: true, parallel: 0, readyCount: 5},
+		{name: "tty with parallel covering every pod", tty: true, parallel: 5, readyCount: 5},
+		{name: "tty with parallel above ready count", tty: true, parallel: 10, readyCount: 5},
+		{name: "stdin with parallel below ready count deadlocks", stdin: true, parallel: 2, readyCount: 5, wantErr: true},
+		{name: "tty with parallel below ready count deadlocks", tty: true, parallel: 1, readyCount: 5, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateStdinParallel(tt.stdin, tt.tty, tt.parallel, tt.readyCount)
+			if tt.wantErr && err == nil {
+				t.Fatalf("validateStdinParallel(%v, %v, %d, %d) expected error, got nil", tt.stdin, tt.tty, tt.parallel, tt.readyCount)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("validateStdinParallel(%v, %v, %d, %d) unexpected error: %v", tt.stdin, tt.tty, tt.parallel, tt.readyCount, err)
+			}
+		})
+	}
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		parallel   int
+		readyCount int
+		want       int
+	}{
+		{name: "zero parallel means unbounded", parallel: 0, readyCount: 5, want: 5},
+		{name: "negative parallel means unbounded", parallel: -1, readyCount: 5, want: 5},
+		{name: "positive parallel below ready count", parallel: 2, readyCount: 5, want: 2},
+		{name: "positive parallel above ready count", parallel: 10, readyCount: 5, want: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := concurrencyLimit(tt.parallel, tt.readyCount); got != tt.want {
+				t.Errorf("concurrencyLimit(%d, %d) = %d, want %d", tt.parallel, tt.readyCount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartitionByReadinessAllReady(t *testing.T) {
+	pod := api.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "a"},
+		Status:     api.PodStatus{Phase: api.PodRunning},
+	}
+
+	ready, pending := partitionByReadiness([]api.Pod{pod})
+
+	if len(ready) != 1 {
+		t.Errorf("ready = %v, want 1 entry", ready)
+	}
+	if len(pending) != 0 {
+		t.Errorf("pending = %v, want empty", pending)
+	}
+}
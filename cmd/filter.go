@@ -0,0 +1,93 @@
+// Copyright © 2016 Apigee Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	api "k8s.io/client-go/pkg/api/v1"
+)
+
+// buildSelector combines labelSelector with any extra label selector expressions using AND
+// semantics, so e.g. "app=hello" plus --selector "tier=web" only matches pods satisfying both.
+func buildSelector(labelSelector string, extra []string) (labels.Selector, error) {
+	selector := labels.NewSelector()
+
+	exprs := append([]string{labelSelector}, extra...)
+	for _, expr := range exprs {
+		expr = strings.TrimSpace(expr)
+		if expr == "" {
+			continue
+		}
+
+		reqs, err := labels.ParseToRequirements(expr)
+		if err != nil {
+			return nil, err
+		}
+
+		selector = selector.Add(reqs...)
+	}
+
+	return selector, nil
+}
+
+// buildFieldSelector combines an arbitrary field selector string with a node name filter,
+// defaulting to fields.Everything() when neither is set.
+func buildFieldSelector(fieldSelector string, node string) (string, error) {
+	var parts []string
+	if fieldSelector != "" {
+		parts = append(parts, fieldSelector)
+	}
+
+	if node != "" {
+		parts = append(parts, fmt.Sprintf("spec.nodeName=%s", node))
+	}
+
+	if len(parts) == 0 {
+		return fields.Everything().String(), nil
+	}
+
+	selector, err := fields.ParseSelector(strings.Join(parts, ","))
+	if err != nil {
+		return "", err
+	}
+
+	return selector.String(), nil
+}
+
+// filterPodsByName keeps only pods whose name matches podNameRegex. An empty regex keeps every pod.
+func filterPodsByName(pods []api.Pod, podNameRegex string) ([]api.Pod, error) {
+	if podNameRegex == "" {
+		return pods, nil
+	}
+
+	re, err := regexp.Compile(podNameRegex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --pod regex %q: %v", podNameRegex, err)
+	}
+
+	filtered := make([]api.Pod, 0, len(pods))
+	for _, pod := range pods {
+		if re.MatchString(pod.Name) {
+			filtered = append(filtered, pod)
+		}
+	}
+
+	return filtered, nil
+}
@@ -0,0 +1,163 @@
+// Copyright © 2016 Apigee Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/client-go/pkg/api/v1"
+)
+
+func TestBuildSelector(t *testing.T) {
+	tests := []struct {
+		name          string
+		labelSelector string
+		extra         []string
+		want          string
+		wantErr       bool
+	}{
+		{name: "label selector only", labelSelector: "app=hello", want: "app=hello"},
+		{
+			name:          "ANDs extra selectors",
+			labelSelector: "app=hello",
+			extra:         []string{"tier=web"},
+			want:          "app=hello,tier=web",
+		},
+		{
+			name:          "ANDs multiple extra selectors",
+			labelSelector: "app=hello",
+			extra:         []string{"tier=web", "env=prod"},
+			want:          "app=hello,env=prod,tier=web",
+		},
+		{name: "blank extra selectors are skipped", labelSelector: "app=hello", extra: []string{"  "}, want: "app=hello"},
+		{name: "invalid expression errors", labelSelector: "app==hello==", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildSelector(tt.labelSelector, tt.extra)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("buildSelector(%q, %v) expected error, got nil", tt.labelSelector, tt.extra)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("buildSelector(%q, %v) unexpected error: %v", tt.labelSelector, tt.extra, err)
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("buildSelector(%q, %v) = %q, want %q", tt.labelSelector, tt.extra, got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildFieldSelector(t *testing.T) {
+	tests := []struct {
+		name          string
+		fieldSelector string
+		node          string
+		want          string
+		wantErr       bool
+	}{
+		{name: "neither set defaults to everything", want: ""},
+		{name: "field selector only", fieldSelector: "status.phase=Running", want: "status.phase=Running"},
+		{name: "node only", node: "node-1", want: "spec.nodeName=node-1"},
+		{
+			name:          "both combine",
+			fieldSelector: "status.phase=Running",
+			node:          "node-1",
+			want:          "spec.nodeName=node-1,status.phase=Running",
+		},
+		{name: "invalid field selector errors", fieldSelector: "===", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildFieldSelector(tt.fieldSelector, tt.node)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("buildFieldSelector(%q, %q) expected error, got nil", tt.fieldSelector, tt.node)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("buildFieldSelector(%q, %q) unexpected error: %v", tt.fieldSelector, tt.node, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("buildFieldSelector(%q, %q) = %q, want %q", tt.fieldSelector, tt.node, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterPodsByName(t *testing.T) {
+	pods := []api.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "hello-abc"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "hello-def"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "world-abc"}},
+	}
+
+	tests := []struct {
+		name         string
+		podNameRegex string
+		want         []string
+		wantErr      bool
+	}{
+		{name: "empty regex keeps every pod", podNameRegex: "", want: []string{"hello-abc", "hello-def", "world-abc"}},
+		{name: "matches a subset", podNameRegex: "^hello-", want: []string{"hello-abc", "hello-def"}},
+		{name: "no matches returns empty slice", podNameRegex: "^nope-", want: []string{}},
+		{name: "invalid regex errors", podNameRegex: "(", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := filterPodsByName(pods, tt.podNameRegex)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("filterPodsByName(pods, %q) expected error, got nil", tt.podNameRegex)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("filterPodsByName(pods, %q) unexpected error: %v", tt.podNameRegex, err)
+			}
+
+			var names []string
+			for _, pod := range got {
+				names = append(names, pod.Name)
+			}
+			if names == nil {
+				names = []string{}
+			}
+
+			if len(names) != len(tt.want) {
+				t.Fatalf("filterPodsByName(pods, %q) = %v, want %v", tt.podNameRegex, names, tt.want)
+			}
+			for i := range names {
+				if names[i] != tt.want[i] {
+					t.Errorf("filterPodsByName(pods, %q) = %v, want %v", tt.podNameRegex, names, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
@@ -15,27 +15,35 @@
 package cmd
 
 import (
-	"errors"
+	"bufio"
+	"context"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
+	"path"
+	"strings"
 	"sync"
-	"bufio"
+	"time"
 
-	"k8s.io/kubernetes/pkg/api"
-	"k8s.io/kubernetes/pkg/client/unversioned"
-	"k8s.io/kubernetes/pkg/client/unversioned/clientcmd"
-	"k8s.io/kubernetes/pkg/fields"
-	"k8s.io/kubernetes/pkg/labels"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	api "k8s.io/client-go/pkg/api/v1"
 
-	"github.com/spf13/cobra"
+	"gocloud.dev/blob"
+	_ "gocloud.dev/blob/gcsblob"
+	_ "gocloud.dev/blob/s3blob"
+
+	"github.com/30x/argonaut/utils"
 	"github.com/fatih/color"
+	"github.com/spf13/cobra"
 )
 
 var containerFlag string
 var tailFlag int
 var followFlag bool
 var colorFlag bool
+var archiveFlag string
 var colors []*color.Color
 
 // logsCmd represents the logs command
@@ -45,10 +53,13 @@ var logsCmd = &cobra.Command{
 	Long: `Print the logs for a container in all matching pods. If the pod has only one container, the container name is optional.
 Examples:
 # Return snapshot logs in all "app=hello" pods with only one container
-k8s-multi-pod logs "app=hello"
+argonaut logs "app=hello"
 
 # Return snapshot logs in the ingress container for all "app=hello" pods
-k8s-multi-pod logs "app=hello" -c ingress`,
+argonaut logs "app=hello" -c ingress
+
+# Also archive each pod's logs to GCS alongside printing them
+argonaut logs "app=hello" --archive gs://my-bucket/argonaut-logs`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) < 1 {
 			fmt.Println("Missing required argument: labelSelector")
@@ -60,13 +71,24 @@ k8s-multi-pod logs "app=hello" -c ingress`,
 		fmt.Println("\nRetrieving logs...this could take a minute.\n")
 
 		// retrieve k8s client via .kube/config
-		client, err := getClient()
+		client, err := utils.GetClientWithOptions(configOptions())
 		if err != nil {
 			fmt.Println(err)
 			return
 		}
 
-		err = GetMultiLogs(client, labelSelector, namespaceFlag, containerFlag, tailFlag, followFlag, colorFlag)
+		err = GetMultiLogs(client, labelSelector, LogsOptions{
+			Namespace:      namespaceFlag,
+			Container:      containerFlag,
+			Tail:           tailFlag,
+			Follow:         followFlag,
+			UseColor:       colorFlag,
+			Archive:        archiveFlag,
+			FieldSelector:  fieldSelectorFlag,
+			PodNameRegex:   podRegexFlag,
+			Node:           nodeFlag,
+			ExtraSelectors: selectorFlag,
+		})
 		if err != nil {
 			fmt.Println(err)
 		}
@@ -75,15 +97,39 @@ k8s-multi-pod logs "app=hello" -c ingress`,
 	},
 }
 
-// GetMultiLogs retrieves all logs for the given label selector
-func GetMultiLogs(client *unversioned.Client, labelSelector string, namespace string, container string, tail int, follow bool, useColor bool) error {
-	// parse given label selector
-	selector, err := labels.Parse(labelSelector)
+// LogsOptions bundles the logs flags that sit alongside the labelSelector being queried, so
+// GetMultiLogs doesn't keep growing a positional parameter with every request that touches it.
+type LogsOptions struct {
+	Namespace      string
+	Container      string
+	Tail           int
+	Follow         bool
+	UseColor       bool
+	Archive        string
+	FieldSelector  string
+	PodNameRegex   string
+	Node           string
+	ExtraSelectors []string
+}
+
+// GetMultiLogs retrieves all logs for the given label selector, narrowed by opts.FieldSelector,
+// opts.PodNameRegex and opts.Node, and ANDed with any opts.ExtraSelectors. When opts.Archive is
+// non-empty it must be a gs:// or s3:// bucket URL (optionally with a key prefix); each pod's log
+// output is additionally written there as <prefix>/<namespace>/<pod>/<container>-<timestamp>.log.
+func GetMultiLogs(client *kubernetes.Clientset, labelSelector string, opts LogsOptions) error {
+	// parse given label selector(s)
+	selector, err := buildSelector(labelSelector, opts.ExtraSelectors)
+	if err != nil {
+		return err
+	}
+
+	resolvedFieldSelector, err := buildFieldSelector(opts.FieldSelector, opts.Node)
 	if err != nil {
 		return err
 	}
 
 	// determine namespace to query
+	namespace := opts.Namespace
 	if namespace == "" {
 		namespace = api.NamespaceDefault
 	}
@@ -91,39 +137,57 @@ func GetMultiLogs(client *unversioned.Client, labelSelector string, namespace st
 	podIntr := client.Pods(namespace)
 
 	// retrieve all pods by label selector
-	pods, err := podIntr.List(api.ListOptions{
-		FieldSelector: fields.Everything(),
-		LabelSelector: selector,
+	pods, err := podIntr.List(metav1.ListOptions{
+		FieldSelector: resolvedFieldSelector,
+		LabelSelector: selector.String(),
 	})
 	if err != nil {
 		return err
 	}
 
+	podItems, err := filterPodsByName(pods.Items, opts.PodNameRegex)
+	if err != nil {
+		return err
+	}
+
 	// notify caller that there were no pods
-	if len(pods.Items) == 0 {
-		return errors.New("No pods in namespace: " + namespace)
+	if len(podItems) == 0 {
+		return fmt.Errorf("No pods in namespace: %s", namespace)
 	}
 
+	var bucket *blob.Bucket
+	var archivePrefix string
+	if opts.Archive != "" {
+		bucket, archivePrefix, err = openArchiveBucket(opts.Archive)
+		if err != nil {
+			return err
+		}
+		defer bucket.Close()
+	}
+
+	runTimestamp := time.Now().UTC().Format("20060102T150405Z")
+
 	var wg sync.WaitGroup
 	var col *color.Color
-	if len(pods.Items) > 7 {
+	useColor := opts.UseColor
+	if len(podItems) > 7 {
 		useColor = false
 	}
 
 	// iterate over pods and get logs
-	for ndx, pod := range pods.Items {
+	for ndx, pod := range podItems {
 		// set pod logging options
 		podLogOpts := &api.PodLogOptions{}
-		if container != "" {
-			podLogOpts.Container = container
+		if opts.Container != "" {
+			podLogOpts.Container = opts.Container
 		}
 
-		if tail != -1 {
-			convTail := int64(tail)
+		if opts.Tail != -1 {
+			convTail := int64(opts.Tail)
 			podLogOpts.TailLines = &convTail
 		}
 
-		podLogOpts.Follow = follow
+		podLogOpts.Follow = opts.Follow
 
 		if useColor {
 			col = colors[ndx]
@@ -139,11 +203,18 @@ func GetMultiLogs(client *unversioned.Client, labelSelector string, namespace st
 			return err
 		}
 
+		archiveWriter, err := openArchiveWriter(bucket, archivePrefix, namespace, pod.Name, archiveContainerName(opts.Container, pod), runTimestamp)
+		if err != nil {
+			stream.Close()
+			return err
+		}
+
 		// gather log request output
-		if follow {
+		if opts.Follow {
 			wg.Add(1)
-			go func(stream io.ReadCloser, podName string, wg *sync.WaitGroup, col *color.Color) {
+			go func(stream io.ReadCloser, archiveWriter io.WriteCloser, podName string, wg *sync.WaitGroup, col *color.Color) {
 				defer stream.Close()
+				defer closeArchiveWriter(archiveWriter)
 				defer wg.Done()
 
 				buf := bufio.NewReader(stream)
@@ -155,14 +226,24 @@ func GetMultiLogs(client *unversioned.Client, labelSelector string, namespace st
 					}
 
 					col.Printf("POD %s: %q\n", podName, line)
+
+					if archiveWriter != nil {
+						archiveWriter.Write(append(line, '\n'))
+					}
 				}
-			}(stream, pod.Name, &wg, col)
+			}(stream, archiveWriter, pod.Name, &wg, col)
 		} else {
 			col.Set()
 			fmt.Println("Logs for pod", pod.Name, ":")
 
-			defer stream.Close()
-			_, err = io.Copy(os.Stdout, stream)
+			dst := io.Writer(os.Stdout)
+			if archiveWriter != nil {
+				dst = io.MultiWriter(os.Stdout, archiveWriter)
+			}
+
+			_, err = io.Copy(dst, stream)
+			stream.Close()
+			closeArchiveWriter(archiveWriter)
 			if err != nil {
 				return err
 			}
@@ -171,32 +252,70 @@ func GetMultiLogs(client *unversioned.Client, labelSelector string, namespace st
 		}
 	}
 
-	if follow {
+	if opts.Follow {
 		wg.Wait()
 	}
 
 	return nil
 }
 
-func getClient() (*unversioned.Client, error) {
-	// retrieve necessary kube config settings
-	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	configOverrides := &clientcmd.ConfigOverrides{}
-	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
-
-	// make a client config with kube config
-	config, err := kubeConfig.ClientConfig()
+// openArchiveBucket parses a gs://bucket/prefix or s3://bucket/prefix archive URL, opening the
+// bucket itself (without the key prefix) via gocloud.dev/blob so the same code path serves both.
+func openArchiveBucket(archive string) (*blob.Bucket, string, error) {
+	u, err := url.Parse(archive)
 	if err != nil {
-		return nil, err
+		return nil, "", fmt.Errorf("invalid archive URL %q: %v", archive, err)
+	}
+
+	if u.Scheme != "gs" && u.Scheme != "s3" {
+		return nil, "", fmt.Errorf("unsupported archive scheme %q, expected gs:// or s3://", u.Scheme)
 	}
 
-	// make a client out of the kube client config
-	client, err := unversioned.New(config)
+	prefix := strings.Trim(u.Path, "/")
+	bucketURL := fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+
+	bucket, err := blob.OpenBucket(context.Background(), bucketURL)
 	if err != nil {
-		return nil, err
+		return nil, "", fmt.Errorf("opening archive bucket %q: %v", bucketURL, err)
+	}
+
+	return bucket, prefix, nil
+}
+
+// openArchiveWriter opens the archive object for a single pod's container, or returns a nil
+// writer when no archive bucket is configured.
+func openArchiveWriter(bucket *blob.Bucket, prefix string, namespace string, pod string, container string, timestamp string) (io.WriteCloser, error) {
+	if bucket == nil {
+		return nil, nil
 	}
 
-	return client, nil
+	key := path.Join(prefix, namespace, pod, fmt.Sprintf("%s-%s.log", container, timestamp))
+
+	return bucket.NewWriter(context.Background(), key, nil)
+}
+
+// archiveContainerName resolves the container name to use in an archive object's key, falling
+// back to the pod's first container when none was requested.
+func archiveContainerName(container string, pod api.Pod) string {
+	if container != "" {
+		return container
+	}
+
+	if len(pod.Spec.Containers) > 0 {
+		return pod.Spec.Containers[0].Name
+	}
+
+	return "default"
+}
+
+func closeArchiveWriter(w io.WriteCloser) {
+	if w == nil {
+		return
+	}
+
+	if err := w.Close(); err != nil {
+		fmt.Fprintln(os.Stderr, "closing archive writer:", err)
+	}
 }
 
 func init() {
@@ -205,6 +324,7 @@ func init() {
 	logsCmd.Flags().IntVarP(&tailFlag, "tail", "t", -1, "Lines of recent log file to display. Defaults to -1, showing all log lines.")
 	logsCmd.Flags().BoolVarP(&followFlag, "follow", "f", false, "Attach the logging streams and watch them")
 	logsCmd.Flags().BoolVarP(&colorFlag, "color", "l", false, "Use color in log output. Up to 7 pods.")
+	logsCmd.Flags().StringVarP(&archiveFlag, "archive", "a", "", "Also archive each pod's logs to this gs:// or s3:// bucket URL")
 
 	colors = []*color.Color{color.New(color.FgBlue), color.New(color.FgWhite), color.New(color.FgGreen), color.New(color.FgMagenta),
 		color.New(color.FgRed), color.New(color.FgCyan), color.New(color.FgYellow)}
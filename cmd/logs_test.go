@@ -0,0 +1,66 @@
+// Copyright © 2016 Apigee Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	api "k8s.io/client-go/pkg/api/v1"
+)
+
+func TestOpenArchiveBucketRejectsBadInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		archive string
+	}{
+		{name: "unsupported scheme", archive: "ftp://bucket/prefix"},
+		{name: "no scheme", archive: "bucket/prefix"},
+		{name: "malformed URL", archive: "://not-a-url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := openArchiveBucket(tt.archive); err == nil {
+				t.Fatalf("openArchiveBucket(%q) expected error, got nil", tt.archive)
+			}
+		})
+	}
+}
+
+func TestArchiveContainerName(t *testing.T) {
+	podWithContainers := api.Pod{
+		Spec: api.PodSpec{Containers: []api.Container{{Name: "first"}, {Name: "second"}}},
+	}
+	podWithNoContainers := api.Pod{}
+
+	tests := []struct {
+		name      string
+		container string
+		pod       api.Pod
+		want      string
+	}{
+		{name: "explicit container wins", container: "second", pod: podWithContainers, want: "second"},
+		{name: "falls back to first container", container: "", pod: podWithContainers, want: "first"},
+		{name: "falls back to default with no containers", container: "", pod: podWithNoContainers, want: "default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := archiveContainerName(tt.container, tt.pod); got != tt.want {
+				t.Errorf("archiveContainerName(%q, pod) = %q, want %q", tt.container, got, tt.want)
+			}
+		})
+	}
+}
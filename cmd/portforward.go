@@ -0,0 +1,261 @@
+// Copyright © 2016 Apigee Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	api "k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+
+	"github.com/30x/argonaut/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var portForwardPorts []string
+
+// portForwardCmd represents the port-forward command
+var portForwardCmd = &cobra.Command{
+	Use:   "port-forward <labelSelector>",
+	Short: "Forward local ports to a port on every matching pod.",
+	Long: `Forward local ports to a port on every matching pod.
+
+Since a local port can only be bound to one pod, sequential local ports are
+allocated per matching pod, e.g. "--port 8080:80" against 3 pods listens on
+8080, 8081 and 8082.
+
+Examples:
+# Forward 8080, 8081, ... to port 80 on every "app=hello" pod
+argonaut port-forward "app=hello" --port 8080:80
+
+# Forward more than one port per pod
+argonaut port-forward "app=hello" --port 8080:80 --port 9090:9000`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			fmt.Println("Missing required argument: labelSelector")
+			return
+		}
+
+		labelSelector := args[0]
+
+		if len(portForwardPorts) == 0 {
+			fmt.Println("Missing required flag: --port")
+			return
+		}
+
+		client, err := utils.GetClientWithOptions(configOptions())
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		err = MultiPortForward(client, labelSelector, namespaceFlag, portForwardPorts, colorFlag)
+		if err != nil {
+			fmt.Println(err)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(portForwardCmd)
+
+	portForwardCmd.Flags().StringArrayVarP(&portForwardPorts, "port", "p", nil, "LOCAL:REMOTE port pair to forward, may be repeated")
+}
+
+// podForwarder holds the per-pod state needed to run and later stop a single pod's forwarder
+type podForwarder struct {
+	pod    string
+	ports  []string
+	stopCh chan struct{}
+}
+
+// stopForwarders closes the stop channel of every forwarder already launched, so a setup
+// failure partway through starting the fleet doesn't leave earlier pods' forwarders running.
+func stopForwarders(forwarders []*podForwarder) {
+	for _, fwd := range forwarders {
+		close(fwd.stopCh)
+	}
+}
+
+// parsePortPairs splits each "LOCAL:REMOTE" pair into its local port number and remote port string.
+func parsePortPairs(portPairs []string) ([]int, []string, error) {
+	locals := make([]int, len(portPairs))
+	remotes := make([]string, len(portPairs))
+	for i, pair := range portPairs {
+		parts := strings.SplitN(pair, ":", 2)
+		if len(parts) != 2 {
+			return nil, nil, fmt.Errorf("Invalid port pair %q, expected LOCAL:REMOTE", pair)
+		}
+
+		local, convErr := strconv.Atoi(parts[0])
+		if convErr != nil {
+			return nil, nil, fmt.Errorf("Invalid local port %q: %v", parts[0], convErr)
+		}
+
+		locals[i] = local
+		remotes[i] = parts[1]
+	}
+
+	return locals, remotes, nil
+}
+
+// podPorts builds the "LOCAL:REMOTE" pairs for the pod at index ndx, offsetting each local port by
+// ndx so every matching pod gets its own sequential local port.
+func podPorts(locals []int, remotes []string, ndx int) []string {
+	ports := make([]string, len(remotes))
+	for i, remote := range remotes {
+		ports[i] = fmt.Sprintf("%d:%s", locals[i]+ndx, remote)
+	}
+
+	return ports
+}
+
+// MultiPortForward opens a SPDY port-forward session to every pod matching labelSelector. Since
+// the same local port can't be bound for more than one pod, each pod is given sequential local
+// ports starting at the LOCAL port requested for the first matching pod.
+func MultiPortForward(client *kubernetes.Clientset, labelSelector string, namespace string, portPairs []string, useColor bool) (err error) {
+	// parse given label selector
+	selector, err := labels.Parse(labelSelector)
+	if err != nil {
+		return err
+	}
+
+	// determine namespace to query
+	if namespace == "" {
+		namespace = api.NamespaceDefault
+	}
+
+	podIntr := client.Pods(namespace)
+
+	// retrieve all pods by label selector
+	pods, err := podIntr.List(metav1.ListOptions{
+		FieldSelector: fields.Everything().String(),
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	// notify caller that there were no pods
+	if len(pods.Items) == 0 {
+		return fmt.Errorf("No pods in namespace: %s", namespace)
+	}
+
+	locals, remotes, err := parsePortPairs(portPairs)
+	if err != nil {
+		return err
+	}
+
+	restConf, err := utils.GetK8sRestConfigWithOptions(configOptions())
+	if err != nil {
+		return err
+	}
+
+	colorLen := len(colors)
+	forwarders := make([]*podForwarder, len(pods.Items))
+
+	// print a legend mapping each pod to the local ports it was allocated
+	fmt.Println("Local port legend:")
+	for ndx, pod := range pods.Items {
+		var col *color.Color
+		if useColor {
+			col = colors[ndx%colorLen]
+		} else {
+			color.NoColor = true
+			col = color.New(color.FgWhite)
+		}
+
+		ports := podPorts(locals, remotes, ndx)
+
+		col.Printf("%s: %s\n", pod.Name, strings.Join(ports, ", "))
+
+		forwarders[ndx] = &podForwarder{
+			pod:    pod.Name,
+			ports:  ports,
+			stopCh: make(chan struct{}),
+		}
+	}
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(forwarders))
+	var started []*podForwarder
+
+	for _, fwd := range forwarders {
+		req := client.CoreV1().RESTClient().Post().
+			Resource(api.ResourcePods.String()).
+			Name(fwd.pod).
+			Namespace(namespace).
+			SubResource("portforward")
+
+		transport, upgrader, err := spdy.RoundTripperFor(restConf)
+		if err != nil {
+			stopForwarders(started)
+			wg.Wait()
+			return err
+		}
+
+		dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", req.URL())
+
+		readyCh := make(chan struct{})
+		pf, err := portforward.New(dialer, fwd.ports, fwd.stopCh, readyCh, os.Stdout, os.Stderr)
+		if err != nil {
+			stopForwarders(started)
+			wg.Wait()
+			return err
+		}
+
+		wg.Add(1)
+		started = append(started, fwd)
+		go func(fwd *podForwarder, pf *portforward.PortForwarder) {
+			defer wg.Done()
+			if err := pf.ForwardPorts(); err != nil {
+				errCh <- fmt.Errorf("port-forward for pod %s: %v", fwd.pod, err)
+			}
+		}(fwd, pf)
+	}
+
+	// close every forwarder's stop channel on SIGINT so they all shut down together
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT)
+	go func() {
+		<-sigCh
+		fmt.Println("\nShutting down port-forward sessions...")
+		for _, fwd := range forwarders {
+			close(fwd.stopCh)
+		}
+	}()
+
+	wg.Wait()
+	close(errCh)
+
+	for fwdErr := range errCh {
+		fmt.Fprintln(os.Stderr, fwdErr)
+	}
+
+	return nil
+}
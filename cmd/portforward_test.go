@@ -0,0 +1,107 @@
+// Copyright © 2016 Apigee Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePortPairs(t *testing.T) {
+	tests := []struct {
+		name        string
+		portPairs   []string
+		wantLocals  []int
+		wantRemotes []string
+		wantErr     bool
+	}{
+		{
+			name:        "single pair",
+			portPairs:   []string{"8080:80"},
+			wantLocals:  []int{8080},
+			wantRemotes: []string{"80"},
+		},
+		{
+			name:        "multiple pairs",
+			portPairs:   []string{"8080:80", "9090:9000"},
+			wantLocals:  []int{8080, 9090},
+			wantRemotes: []string{"80", "9000"},
+		},
+		{
+			name:      "missing colon",
+			portPairs: []string{"8080"},
+			wantErr:   true,
+		},
+		{
+			name:      "non-numeric local port",
+			portPairs: []string{"abc:80"},
+			wantErr:   true,
+		},
+		{
+			name:        "remote port as name, not numeric",
+			portPairs:   []string{"8080:http"},
+			wantLocals:  []int{8080},
+			wantRemotes: []string{"http"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locals, remotes, err := parsePortPairs(tt.portPairs)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parsePortPairs(%v) expected error, got nil", tt.portPairs)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parsePortPairs(%v) unexpected error: %v", tt.portPairs, err)
+			}
+
+			if !reflect.DeepEqual(locals, tt.wantLocals) {
+				t.Errorf("locals = %v, want %v", locals, tt.wantLocals)
+			}
+
+			if !reflect.DeepEqual(remotes, tt.wantRemotes) {
+				t.Errorf("remotes = %v, want %v", remotes, tt.wantRemotes)
+			}
+		})
+	}
+}
+
+func TestPodPorts(t *testing.T) {
+	locals := []int{8080, 9090}
+	remotes := []string{"80", "9000"}
+
+	tests := []struct {
+		name string
+		ndx  int
+		want []string
+	}{
+		{name: "first pod, no offset", ndx: 0, want: []string{"8080:80", "9090:9000"}},
+		{name: "second pod, offset by one", ndx: 1, want: []string{"8081:80", "9091:9000"}},
+		{name: "third pod, offset by two", ndx: 2, want: []string{"8082:80", "9092:9000"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := podPorts(locals, remotes, tt.ndx)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("podPorts(%v, %v, %d) = %v, want %v", locals, remotes, tt.ndx, got, tt.want)
+			}
+		})
+	}
+}
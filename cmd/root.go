@@ -0,0 +1,76 @@
+// Copyright © 2016 Apigee Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/30x/argonaut/utils"
+	"github.com/spf13/cobra"
+)
+
+var namespaceFlag string
+var kubeconfigFlag string
+var contextFlag string
+var asUserFlag string
+var asGroupFlag []string
+var tokenFlag string
+var fieldSelectorFlag string
+var podRegexFlag string
+var nodeFlag string
+var selectorFlag []string
+
+// RootCmd is the base command all subcommands attach to
+var RootCmd = &cobra.Command{
+	Use:   "argonaut",
+	Short: "Run commands against every pod matching a label selector.",
+	Long: `argonaut runs exec, logs and port-forward against every pod matching a
+label selector, rather than one pod at a time.`,
+}
+
+// Execute adds all child commands to the root command and runs it
+func Execute() {
+	if err := RootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// configOptions builds a utils.ConfigOptions from the persistent kubeconfig/context/auth flags,
+// so every subcommand honors them the same way.
+func configOptions() utils.ConfigOptions {
+	return utils.ConfigOptions{
+		KubeconfigPath:    kubeconfigFlag,
+		Context:           contextFlag,
+		Namespace:         namespaceFlag,
+		BearerToken:       tokenFlag,
+		ImpersonateUser:   asUserFlag,
+		ImpersonateGroups: asGroupFlag,
+	}
+}
+
+func init() {
+	RootCmd.PersistentFlags().StringVarP(&namespaceFlag, "namespace", "n", "", "Kubernetes namespace to query. Defaults to \"default\".")
+	RootCmd.PersistentFlags().StringVar(&kubeconfigFlag, "kubeconfig", "", "Path to the kubeconfig file to use")
+	RootCmd.PersistentFlags().StringVar(&contextFlag, "context", "", "The name of the kubeconfig context to use")
+	RootCmd.PersistentFlags().StringVar(&asUserFlag, "as", "", "Username to impersonate for the operation")
+	RootCmd.PersistentFlags().StringArrayVar(&asGroupFlag, "as-group", nil, "Group to impersonate for the operation, may be repeated")
+	RootCmd.PersistentFlags().StringVar(&tokenFlag, "token", "", "Bearer token to use for authentication")
+	RootCmd.PersistentFlags().StringVar(&fieldSelectorFlag, "field-selector", "", "Field selector to narrow the pod list, passed straight to the list request")
+	RootCmd.PersistentFlags().StringVar(&podRegexFlag, "pod", "", "Regex matched against pod names after listing, to further narrow the match")
+	RootCmd.PersistentFlags().StringVar(&nodeFlag, "node", "", "Only match pods scheduled on this node")
+	RootCmd.PersistentFlags().StringArrayVar(&selectorFlag, "selector", nil, "Additional label selector, ANDed with the positional one, may be repeated")
+}
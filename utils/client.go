@@ -6,10 +6,29 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 )
 
-// GetClient retrieves a kubernetes client
+// ConfigOptions customizes how GetK8sRestConfigWithOptions locates and authenticates a cluster,
+// mirroring the overrides kubectl's Factory exposes via ClientConfig().
+type ConfigOptions struct {
+	KubeconfigPath    string
+	Context           string
+	Cluster           string
+	User              string
+	Namespace         string
+	BearerToken       string
+	ImpersonateUser   string
+	ImpersonateGroups []string
+	InCluster         bool
+}
+
+// GetClient retrieves a kubernetes client using default kubeconfig loading rules and no overrides.
 func GetClient() (*kubernetes.Clientset, error) {
+	return GetClientWithOptions(ConfigOptions{})
+}
+
+// GetClientWithOptions retrieves a kubernetes client built from opts.
+func GetClientWithOptions(opts ConfigOptions) (*kubernetes.Clientset, error) {
 	// make a client config with kube config
-	config, err := GetK8sRestConfig()
+	config, err := GetK8sRestConfigWithOptions(opts)
 	if err != nil {
 		return nil, err
 	}
@@ -18,11 +37,56 @@ func GetClient() (*kubernetes.Clientset, error) {
 	return kubernetes.NewForConfig(config)
 }
 
-// GetK8sRestConfig returns a k8s rest client config
+// GetK8sRestConfig returns a k8s rest client config using default loading rules and no overrides.
+// Kept as a thin wrapper around GetK8sRestConfigWithOptions for backward compatibility.
 func GetK8sRestConfig() (conf *rest.Config, err error) {
+	return GetK8sRestConfigWithOptions(ConfigOptions{})
+}
+
+// GetK8sRestConfigWithOptions returns a k8s rest client config built from opts. When
+// opts.InCluster is set, it falls back to rest.InClusterConfig(); otherwise it honors the
+// kubeconfig path, context, cluster, user, namespace, bearer token and impersonation overrides
+// carried by opts, matching how kubectl's Factory exposes ClientConfig() with overrides.
+func GetK8sRestConfigWithOptions(opts ConfigOptions) (conf *rest.Config, err error) {
+	if opts.InCluster {
+		return rest.InClusterConfig()
+	}
+
 	// retrieve necessary kube config settings
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if opts.KubeconfigPath != "" {
+		loadingRules.ExplicitPath = opts.KubeconfigPath
+	}
+
 	configOverrides := &clientcmd.ConfigOverrides{}
+	if opts.Context != "" {
+		configOverrides.CurrentContext = opts.Context
+	}
+
+	if opts.Cluster != "" {
+		configOverrides.Context.Cluster = opts.Cluster
+	}
+
+	if opts.User != "" {
+		configOverrides.Context.AuthInfo = opts.User
+	}
+
+	if opts.Namespace != "" {
+		configOverrides.Context.Namespace = opts.Namespace
+	}
+
+	if opts.BearerToken != "" {
+		configOverrides.AuthInfo.Token = opts.BearerToken
+	}
+
+	if opts.ImpersonateUser != "" {
+		configOverrides.AuthInfo.Impersonate = opts.ImpersonateUser
+	}
+
+	if len(opts.ImpersonateGroups) > 0 {
+		configOverrides.AuthInfo.ImpersonateGroups = opts.ImpersonateGroups
+	}
+
 	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
 
 	return kubeConfig.ClientConfig()